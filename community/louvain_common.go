@@ -0,0 +1,56 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import "github.com/gonum/graph"
+
+// community is a single group of original-graph nodes aggregated into
+// one node of a reduced graph.
+type community struct {
+	id    int
+	nodes []graph.Node
+}
+
+// positiveWeightFuncFor returns a function that returns the weight of the
+// edge between x and y in g, or zero if no such edge exists. It panics if
+// g holds an edge with a negative weight.
+func positiveWeightFuncFor(g graph.Graph) func(x, y graph.Node) float64 {
+	return func(x, y graph.Node) float64 {
+		e := g.Edge(x, y)
+		if e == nil {
+			return 0
+		}
+		w := e.Weight()
+		if w < 0 {
+			panic("community: unexpected negative edge weight")
+		}
+		return w
+	}
+}
+
+// signedWeight holds the positive and negative components of a signed
+// edge weight, or of a node or community strength aggregated from
+// them.
+type signedWeight struct {
+	pos, neg float64
+}
+
+// splitWeightFuncFor returns a function that splits the weight of the
+// edge between x and y in g into its positive and negative components:
+// for an edge of weight w, it returns (w, 0) if w is non-negative and
+// (0, -w) otherwise. It returns (0, 0) if no such edge exists.
+func splitWeightFuncFor(g graph.Graph) func(x, y graph.Node) (pos, neg float64) {
+	return func(x, y graph.Node) (pos, neg float64) {
+		e := g.Edge(x, y)
+		if e == nil {
+			return 0, 0
+		}
+		w := e.Weight()
+		if w < 0 {
+			return 0, -w
+		}
+		return w, 0
+	}
+}
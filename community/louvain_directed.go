@@ -0,0 +1,507 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+// ReducedDirected is a directed graph of communities derived from a
+// parent graph by reduction. Each node in a ReducedDirected holds the
+// set of original-graph nodes that were merged into it.
+type ReducedDirected struct {
+	// nodes holds the communities that are the
+	// nodes of the reduced graph. A community's
+	// index in nodes is its node ID in this graph.
+	nodes []community
+
+	// edges holds the aggregated positive and
+	// negative edge weight between, and self-loop
+	// weight within, communities.
+	edges map[[2]int]signedWeight
+
+	// structure holds, for each node, the nodes
+	// of the finer-grained reduction in parent
+	// that were merged to form it. It is indexed
+	// identically to nodes.
+	structure [][]graph.Node
+
+	// parent is the finer-grained reduction that
+	// this graph was built from by one round of
+	// local moving and aggregation. parent is nil
+	// when this is the first reduction taken
+	// directly from the original graph.
+	parent *ReducedDirected
+}
+
+// reduceDirected returns the identity reduction of g: a ReducedDirected
+// in which every node of g is its own community, with node IDs
+// assigned in g's own node ID order, and structure holding each
+// node's own identity since there is no finer-grained graph below it.
+// Negative edge weights are retained, split from the positive ones, so
+// that signed modularity can be computed over the reduction.
+func reduceDirected(g graph.Directed) *ReducedDirected {
+	nodes := g.Nodes()
+	r := &ReducedDirected{
+		nodes:     make([]community, len(nodes)),
+		edges:     make(map[[2]int]signedWeight),
+		structure: make([][]graph.Node, len(nodes)),
+	}
+	// Assign community IDs in node ID order so that the
+	// identity reduction's IDs match the original graph's.
+	byID := make(map[int]int, len(nodes))
+	ids := make([]int, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+	sortInts(ids)
+	for i, id := range ids {
+		byID[id] = i
+		r.nodes[i] = community{id: i, nodes: []graph.Node{simple.Node(id)}}
+		r.structure[i] = []graph.Node{simple.Node(id)}
+	}
+	split := splitWeightFuncFor(g)
+	for _, u := range nodes {
+		for _, v := range g.From(u) {
+			pos, neg := split(u, v)
+			if pos == 0 && neg == 0 {
+				continue
+			}
+			key := [2]int{byID[u.ID()], byID[v.ID()]}
+			e := r.edges[key]
+			e.pos += pos
+			e.neg += neg
+			r.edges[key] = e
+		}
+	}
+	return r
+}
+
+func sortInts(a []int) {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j-1] > a[j]; j-- {
+			a[j-1], a[j] = a[j], a[j-1]
+		}
+	}
+}
+
+// Has returns whether n exists as a node in the graph.
+func (g *ReducedDirected) Has(n graph.Node) bool {
+	id := n.ID()
+	return id >= 0 && id < len(g.nodes)
+}
+
+// Nodes returns the nodes of the graph, one per community.
+func (g *ReducedDirected) Nodes() []graph.Node {
+	nodes := make([]graph.Node, len(g.nodes))
+	for i := range g.nodes {
+		nodes[i] = simple.Node(i)
+	}
+	return nodes
+}
+
+// From returns the nodes reachable by a directed edge from n, excluding n.
+func (g *ReducedDirected) From(n graph.Node) []graph.Node {
+	var to []graph.Node
+	for other := range g.nodes {
+		if other == n.ID() {
+			continue
+		}
+		if _, ok := g.edges[[2]int{n.ID(), other}]; ok {
+			to = append(to, simple.Node(other))
+		}
+	}
+	return to
+}
+
+// To returns the nodes that have a directed edge to n, excluding n.
+func (g *ReducedDirected) To(n graph.Node) []graph.Node {
+	var from []graph.Node
+	for other := range g.nodes {
+		if other == n.ID() {
+			continue
+		}
+		if _, ok := g.edges[[2]int{other, n.ID()}]; ok {
+			from = append(from, simple.Node(other))
+		}
+	}
+	return from
+}
+
+// HasEdgeBetween returns whether an edge exists between x and y in
+// either direction.
+func (g *ReducedDirected) HasEdgeBetween(x, y graph.Node) bool {
+	return g.HasEdgeFromTo(x, y) || g.HasEdgeFromTo(y, x)
+}
+
+// HasEdgeFromTo returns whether an edge exists from u to v.
+func (g *ReducedDirected) HasEdgeFromTo(u, v graph.Node) bool {
+	_, ok := g.edges[[2]int{u.ID(), v.ID()}]
+	return ok
+}
+
+// Edge returns the edge from u to v if one exists, and nil otherwise.
+func (g *ReducedDirected) Edge(u, v graph.Node) graph.Edge {
+	e, ok := g.edges[[2]int{u.ID(), v.ID()}]
+	if !ok {
+		return nil
+	}
+	return simple.Edge{F: u, T: v, W: e.pos - e.neg}
+}
+
+// weight returns the aggregated positive and negative edge weight from
+// u to v, including self-loop weight when u and v are the same
+// community.
+func (g *ReducedDirected) weight(u, v int) (pos, neg float64) {
+	e := g.edges[[2]int{u, v}]
+	return e.pos, e.neg
+}
+
+// Communities returns the community structure of the graph in terms of
+// the original graph's nodes.
+func (g *ReducedDirected) Communities() [][]graph.Node {
+	communities := make([][]graph.Node, len(g.nodes))
+	for i, c := range g.nodes {
+		nodes := make([]graph.Node, len(c.nodes))
+		copy(nodes, c.nodes)
+		communities[i] = nodes
+	}
+	return communities
+}
+
+// Structure returns the sub-community layout of the graph: the
+// element at a given node ID holds the nodes of Expanded() that were
+// merged to form that node.
+func (g *ReducedDirected) Structure() [][]graph.Node {
+	structure := make([][]graph.Node, len(g.structure))
+	for i, s := range g.structure {
+		nodes := make([]graph.Node, len(s))
+		copy(nodes, s)
+		structure[i] = nodes
+	}
+	return structure
+}
+
+// Expanded returns the finer-grained ReducedGraph that this graph was
+// constructed from, or nil if this is the first level of reduction.
+func (g *ReducedDirected) Expanded() ReducedGraph {
+	if g.parent == nil {
+		return nil
+	}
+	return g.parent
+}
+
+// LouvainDirected returns the community structure of g at the given
+// resolution using the Louvain modularization algorithm. If src is not
+// nil it is used to control the randomized iteration order of the
+// local moving phase; if src is nil, global randomness is used.
+//
+// LouvainDirected returns nil if g has no nodes.
+func LouvainDirected(g graph.Directed, resolution float64, src *rand.Rand) *ReducedDirected {
+	if len(g.Nodes()) == 0 {
+		return nil
+	}
+
+	rnd := rand.Intn
+	if src != nil {
+		rnd = src.Intn
+	}
+
+	current := reduceDirected(g)
+	for {
+		communities := louvainDirectedLocalMove(current, resolution, rnd)
+		if len(communities) == len(current.nodes) {
+			// No nodes were moved; we have converged.
+			return current
+		}
+
+		next := aggregateDirected(current, communities)
+		next.parent = current
+		current = next
+	}
+}
+
+// aggregateDirected builds the coarser ReducedDirected formed by
+// collapsing each of communities (indices into cur.nodes) into a
+// single node, expressed in terms of the original graph's nodes.
+func aggregateDirected(cur *ReducedDirected, communities [][]int) *ReducedDirected {
+	expanded := make([][]graph.Node, len(communities))
+	for cid, members := range communities {
+		for _, id := range members {
+			expanded[cid] = append(expanded[cid], cur.nodes[id].nodes...)
+		}
+	}
+
+	r := &ReducedDirected{
+		nodes:     make([]community, len(communities)),
+		edges:     make(map[[2]int]signedWeight),
+		structure: make([][]graph.Node, len(communities)),
+	}
+	nodeCommunity := make(map[int]int, len(cur.nodes))
+	for cid, members := range communities {
+		r.nodes[cid] = community{id: cid, nodes: expanded[cid]}
+		for _, id := range members {
+			nodeCommunity[id] = cid
+			r.structure[cid] = append(r.structure[cid], simple.Node(id))
+		}
+	}
+	for key, w := range cur.edges {
+		u, v := nodeCommunity[key[0]], nodeCommunity[key[1]]
+		rkey := [2]int{u, v}
+		e := r.edges[rkey]
+		e.pos += w.pos
+		e.neg += w.neg
+		r.edges[rkey] = e
+	}
+	return r
+}
+
+// directedDegrees returns the out- and in-strength of every node of g,
+// indexed by node ID, along with g's overall positive and negative
+// edge weight.
+func directedDegrees(g *ReducedDirected) (out, in []signedWeight, total signedWeight) {
+	n := len(g.nodes)
+	out = make([]signedWeight, n)
+	in = make([]signedWeight, n)
+	for key, w := range g.edges {
+		out[key[0]].pos += w.pos
+		out[key[0]].neg += w.neg
+		in[key[1]].pos += w.pos
+		in[key[1]].neg += w.neg
+		total.pos += w.pos
+		total.neg += w.neg
+	}
+	return out, in, total
+}
+
+// louvainDirectedLocalMove performs the local moving phase of the
+// Louvain algorithm on g, returning the resulting communities as
+// slices of node IDs in g. If no node changes community, the returned
+// communities are the singleton communities of g, one per node.
+func louvainDirectedLocalMove(g *ReducedDirected, resolution float64, rnd func(int) int) [][]int {
+	out, in, total := directedDegrees(g)
+	return louvainDirectedLocalMoveWithDegrees(g, resolution, rnd, out, in, total)
+}
+
+// louvainDirectedLocalMoveWithDegrees performs the local moving phase
+// of the Louvain algorithm on g exactly as louvainDirectedLocalMove
+// does, except that it normalizes the resolution term of directedGain
+// against the caller-supplied out-strength, in-strength and total
+// weight rather than recomputing them from g's own edges. This lets a
+// refinement pass restricted to a subgraph still maximize modularity
+// against the total weight of the network the subgraph was drawn
+// from, rather than against the subgraph's own, smaller, internal
+// weight.
+func louvainDirectedLocalMoveWithDegrees(g *ReducedDirected, resolution float64, rnd func(int) int, out, in []signedWeight, total signedWeight) [][]int {
+	n := len(g.nodes)
+	comm := make([]int, n)
+	for i := range comm {
+		comm[i] = i
+	}
+
+	if total.pos+total.neg == 0 {
+		return toCommunities(comm)
+	}
+
+	commOut := make([]signedWeight, n)
+	commIn := make([]signedWeight, n)
+	copy(commOut, out)
+	copy(commIn, in)
+
+	moved := true
+	for moved {
+		moved = false
+		order := rndPerm(n, rnd)
+		for _, u := range order {
+			cu := comm[u]
+
+			// Remove u from its current community.
+			commOut[cu].pos -= out[u].pos
+			commOut[cu].neg -= out[u].neg
+			commIn[cu].pos -= in[u].pos
+			commIn[cu].neg -= in[u].neg
+
+			gain := make(map[int]float64)
+			gain[cu] = 0
+			// candidates holds community IDs in the fixed,
+			// rnd-independent order in which they were first
+			// seen, so that the best-move comparison below is
+			// not subject to Go's randomized map iteration
+			// order.
+			candidates := []int{cu}
+			for v := 0; v < n; v++ {
+				if v == u {
+					continue
+				}
+				cv := comm[v]
+				if _, ok := gain[cv]; ok {
+					continue
+				}
+				gain[cv] = directedGain(g, u, cv, comm, out[u], in[u], commOut[cv], commIn[cv], total, resolution)
+				candidates = append(candidates, cv)
+			}
+
+			best, bestGain := cu, 0.0
+			for _, c := range candidates {
+				if dq := gain[c]; dq > bestGain {
+					best, bestGain = c, dq
+				}
+			}
+
+			comm[u] = best
+			commOut[best].pos += out[u].pos
+			commOut[best].neg += out[u].neg
+			commIn[best].pos += in[u].pos
+			commIn[best].neg += in[u].neg
+			if best != cu {
+				moved = true
+			}
+		}
+	}
+
+	return toCommunities(comm)
+}
+
+// directedGain returns the change in signed Q, using the
+// Gómez–Jensen–Arenas formulation, from moving node u, with
+// out-strength uOut and in-strength uIn, into community c which
+// (excluding u) has aggregated out-strength cOut and in-strength cIn.
+// total is the graph's overall positive and negative edge weight.
+func directedGain(g *ReducedDirected, u, c int, comm []int, uOut, uIn, cOut, cIn, total signedWeight, resolution float64) float64 {
+	var toCPos, toCNeg, fromCPos, fromCNeg float64
+	for v, cv := range comm {
+		if cv != c || v == u {
+			continue
+		}
+		pos, neg := g.weight(u, v)
+		toCPos += pos
+		toCNeg += neg
+		pos, neg = g.weight(v, u)
+		fromCPos += pos
+		fromCNeg += neg
+	}
+
+	var posGain float64
+	if total.pos != 0 {
+		posGain = (toCPos + fromCPos) - resolution*(uOut.pos*cIn.pos+uIn.pos*cOut.pos)/total.pos
+	}
+	var negGain float64
+	if total.neg != 0 {
+		negGain = (toCNeg + fromCNeg) - resolution*(uOut.neg*cIn.neg+uIn.neg*cOut.neg)/total.neg
+	}
+	return (posGain - negGain) / (total.pos + total.neg)
+}
+
+// toCommunities groups node IDs by their community assignment.
+func toCommunities(comm []int) [][]int {
+	groups := make(map[int][]int)
+	for id, c := range comm {
+		groups[c] = append(groups[c], id)
+	}
+	communities := make([][]int, 0, len(groups))
+	for _, members := range groups {
+		communities = append(communities, members)
+	}
+	return communities
+}
+
+// rndPerm returns a random permutation of the integers [0, n) using rnd
+// to generate random indices.
+func rndPerm(n int, rnd func(int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := rnd(i + 1)
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// qDirected returns the signed modularity of g at the given resolution,
+// using the Gómez–Jensen–Arenas formulation, with communities defining
+// the partition over g's own nodes if it is not nil, or the
+// communities already held by g otherwise.
+func qDirected(g *ReducedDirected, communities [][]graph.Node, resolution float64) float64 {
+	if g == nil {
+		return math.NaN()
+	}
+	group := make(map[int]int, len(g.nodes))
+	if communities == nil {
+		for i := range g.nodes {
+			group[i] = i
+		}
+	} else {
+		for gid, nodes := range communities {
+			for _, n := range nodes {
+				group[n.ID()] = gid
+			}
+		}
+	}
+
+	out := make(map[int]signedWeight)
+	in := make(map[int]signedWeight)
+	var total signedWeight
+	for key, w := range g.edges {
+		o := out[key[0]]
+		o.pos += w.pos
+		o.neg += w.neg
+		out[key[0]] = o
+
+		i := in[key[1]]
+		i.pos += w.pos
+		i.neg += w.neg
+		in[key[1]] = i
+
+		total.pos += w.pos
+		total.neg += w.neg
+	}
+	m := total.pos + total.neg
+	if m == 0 {
+		return math.NaN()
+	}
+
+	var internalPos, internalNeg float64
+	for key, w := range g.edges {
+		if group[key[0]] == group[key[1]] {
+			internalPos += w.pos
+			internalNeg += w.neg
+		}
+	}
+
+	commOut := make(map[int]signedWeight)
+	commIn := make(map[int]signedWeight)
+	for id, gid := range group {
+		co := commOut[gid]
+		co.pos += out[id].pos
+		co.neg += out[id].neg
+		commOut[gid] = co
+
+		ci := commIn[gid]
+		ci.pos += in[id].pos
+		ci.neg += in[id].neg
+		commIn[gid] = ci
+	}
+	var correctionPos, correctionNeg float64
+	for gid := range commOut {
+		correctionPos += commOut[gid].pos * commIn[gid].pos
+		correctionNeg += commOut[gid].neg * commIn[gid].neg
+	}
+
+	var qPos, qNeg float64
+	if total.pos != 0 {
+		qPos = internalPos - resolution*correctionPos/total.pos
+	}
+	if total.neg != 0 {
+		qNeg = internalNeg - resolution*correctionNeg/total.neg
+	}
+
+	return (qPos - qNeg) / m
+}
@@ -0,0 +1,327 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+// Layer is a single layer of a multiplex graph: a directed graph and
+// the weight it contributes to the multiplex modularity. A negative
+// Weight flips the sign of the layer's contribution, allowing an
+// anti-correlated layer to drive nodes apart rather than together.
+type Layer struct {
+	Graph  graph.Directed
+	Weight float64
+}
+
+// multiplexLayer holds the per-community-pair edge weight aggregated
+// for a single layer of a ReducedDirectedMultiplex, along with the
+// layer weight it was built with.
+type multiplexLayer struct {
+	weight float64
+	edges  map[[2]int]signedWeight
+}
+
+// ReducedDirectedMultiplex is a directed multiplex graph of communities
+// derived from a set of layers sharing a common node set, by reduction.
+// Each node holds the set of original-graph nodes merged into it, and
+// each layer retains its own aggregated edge weights so that modularity
+// can be computed as a weighted sum of per-layer contributions.
+type ReducedDirectedMultiplex struct {
+	nodes  []community
+	layers []multiplexLayer
+
+	parent *ReducedDirectedMultiplex
+}
+
+// reduceDirectedMultiplex returns a ReducedDirectedMultiplex of layers
+// where each node is its own community, or, if communities is not nil,
+// where each element of communities is a single community.
+func reduceDirectedMultiplex(layers []Layer, communities [][]graph.Node) *ReducedDirectedMultiplex {
+	var nodes []graph.Node
+	for _, l := range layers {
+		nodes = l.Graph.Nodes()
+		break
+	}
+
+	r := &ReducedDirectedMultiplex{
+		layers: make([]multiplexLayer, len(layers)),
+	}
+
+	if communities == nil {
+		ids := make([]int, len(nodes))
+		for i, n := range nodes {
+			ids[i] = n.ID()
+		}
+		sortInts(ids)
+		byID := make(map[int]int, len(nodes))
+		r.nodes = make([]community, len(nodes))
+		for i, id := range ids {
+			byID[id] = i
+			r.nodes[i] = community{id: i, nodes: []graph.Node{simple.Node(id)}}
+		}
+		for li, l := range layers {
+			edges := make(map[[2]int]signedWeight)
+			weight := positiveWeightFuncFor(l.Graph)
+			for _, u := range l.Graph.Nodes() {
+				for _, v := range l.Graph.From(u) {
+					w := weight(u, v)
+					if w == 0 {
+						continue
+					}
+					key := [2]int{byID[u.ID()], byID[v.ID()]}
+					e := edges[key]
+					e.pos += w
+					edges[key] = e
+				}
+			}
+			r.layers[li] = multiplexLayer{weight: l.Weight, edges: edges}
+		}
+		return r
+	}
+
+	r.nodes = make([]community, len(communities))
+	nodeCommunity := make(map[int]int)
+	for cid, cnodes := range communities {
+		r.nodes[cid] = community{id: cid, nodes: cnodes}
+		for _, n := range cnodes {
+			nodeCommunity[n.ID()] = cid
+		}
+	}
+	for li, l := range layers {
+		edges := make(map[[2]int]signedWeight)
+		weight := positiveWeightFuncFor(l.Graph)
+		for _, cnodes := range communities {
+			for _, u := range cnodes {
+				for _, v := range l.Graph.From(u) {
+					w := weight(u, v)
+					if w == 0 {
+						continue
+					}
+					key := [2]int{nodeCommunity[u.ID()], nodeCommunity[v.ID()]}
+					e := edges[key]
+					e.pos += w
+					edges[key] = e
+				}
+			}
+		}
+		r.layers[li] = multiplexLayer{weight: l.Weight, edges: edges}
+	}
+	return r
+}
+
+// Communities returns the community structure of the graph in terms of
+// the original graph's nodes.
+func (g *ReducedDirectedMultiplex) Communities() [][]graph.Node {
+	communities := make([][]graph.Node, len(g.nodes))
+	for i, c := range g.nodes {
+		cnodes := make([]graph.Node, len(c.nodes))
+		copy(cnodes, c.nodes)
+		communities[i] = cnodes
+	}
+	return communities
+}
+
+// Expanded returns the finer-grained ReducedDirectedMultiplex that this
+// graph was constructed from, or nil if this is the first level of
+// reduction.
+func (g *ReducedDirectedMultiplex) Expanded() *ReducedDirectedMultiplex {
+	return g.parent
+}
+
+// LouvainDirectedMultiplex returns the community structure of the
+// multiplex graph described by layers at the given resolution, using
+// the Louvain modularization algorithm generalized to sum modularity
+// contributions across layers. If src is not nil it is used to control
+// the randomized iteration order of the local moving phase; if src is
+// nil, global randomness is used.
+//
+// LouvainDirectedMultiplex returns nil if layers is empty or its first
+// layer has no nodes. All layers must share the same node set.
+func LouvainDirectedMultiplex(layers []Layer, resolution float64, src *rand.Rand) *ReducedDirectedMultiplex {
+	if len(layers) == 0 || len(layers[0].Graph.Nodes()) == 0 {
+		return nil
+	}
+
+	rnd := rand.Intn
+	if src != nil {
+		rnd = src.Intn
+	}
+
+	current := reduceDirectedMultiplex(layers, nil)
+	for {
+		communities := louvainDirectedMultiplexLocalMove(current, resolution, rnd)
+		if len(communities) == len(current.nodes) {
+			return current
+		}
+
+		next := aggregateDirectedMultiplex(current, communities)
+		next.parent = current
+		current = next
+	}
+}
+
+// aggregateDirectedMultiplex builds the coarser ReducedDirectedMultiplex
+// formed by collapsing each of communities into a single node.
+func aggregateDirectedMultiplex(cur *ReducedDirectedMultiplex, communities [][]int) *ReducedDirectedMultiplex {
+	expanded := make([][]graph.Node, len(communities))
+	for cid, members := range communities {
+		for _, id := range members {
+			expanded[cid] = append(expanded[cid], cur.nodes[id].nodes...)
+		}
+	}
+
+	r := &ReducedDirectedMultiplex{
+		nodes:  make([]community, len(communities)),
+		layers: make([]multiplexLayer, len(cur.layers)),
+	}
+	nodeCommunity := make(map[int]int, len(cur.nodes))
+	for cid, members := range communities {
+		r.nodes[cid] = community{id: cid, nodes: expanded[cid]}
+		for _, id := range members {
+			nodeCommunity[id] = cid
+		}
+	}
+	for li, l := range cur.layers {
+		edges := make(map[[2]int]signedWeight)
+		for key, w := range l.edges {
+			u, v := nodeCommunity[key[0]], nodeCommunity[key[1]]
+			rkey := [2]int{u, v}
+			e := edges[rkey]
+			e.pos += w.pos
+			edges[rkey] = e
+		}
+		r.layers[li] = multiplexLayer{weight: l.weight, edges: edges}
+	}
+	return r
+}
+
+// louvainDirectedMultiplexLocalMove performs the local moving phase of
+// the Louvain algorithm on g, maximizing the layer-weighted sum of
+// per-layer directed modularity gains.
+func louvainDirectedMultiplexLocalMove(g *ReducedDirectedMultiplex, resolution float64, rnd func(int) int) [][]int {
+	n := len(g.nodes)
+	comm := make([]int, n)
+	for i := range comm {
+		comm[i] = i
+	}
+
+	type layerStrengths struct {
+		out, in         []float64
+		commOut, commIn []float64
+		m               float64
+	}
+	strengths := make([]layerStrengths, len(g.layers))
+	var anyNonZero bool
+	for li, l := range g.layers {
+		out := make([]float64, n)
+		in := make([]float64, n)
+		var m float64
+		for key, w := range l.edges {
+			out[key[0]] += w.pos
+			in[key[1]] += w.pos
+			m += w.pos
+		}
+		if m != 0 {
+			anyNonZero = true
+		}
+		commOut := make([]float64, n)
+		commIn := make([]float64, n)
+		copy(commOut, out)
+		copy(commIn, in)
+		strengths[li] = layerStrengths{out: out, in: in, commOut: commOut, commIn: commIn, m: m}
+	}
+	if !anyNonZero {
+		return toCommunities(comm)
+	}
+
+	moved := true
+	for moved {
+		moved = false
+		order := rndPerm(n, rnd)
+		for _, u := range order {
+			cu := comm[u]
+			for li := range g.layers {
+				strengths[li].commOut[cu] -= strengths[li].out[u]
+				strengths[li].commIn[cu] -= strengths[li].in[u]
+			}
+
+			gain := make(map[int]float64)
+			gain[cu] = 0
+			// candidates holds community IDs in the fixed,
+			// rnd-independent order in which they were first
+			// seen, so that the best-move comparison below is
+			// not subject to Go's randomized map iteration
+			// order.
+			candidates := []int{cu}
+			for v := 0; v < n; v++ {
+				if v == u {
+					continue
+				}
+				cv := comm[v]
+				if _, ok := gain[cv]; ok {
+					continue
+				}
+				var dq float64
+				for li, l := range g.layers {
+					s := strengths[li]
+					if s.m == 0 {
+						continue
+					}
+					dq += l.weight * directedGain(&ReducedDirected{edges: l.edges}, u, cv, comm,
+						signedWeight{pos: s.out[u]}, signedWeight{pos: s.in[u]},
+						signedWeight{pos: s.commOut[cv]}, signedWeight{pos: s.commIn[cv]},
+						signedWeight{pos: s.m}, resolution)
+				}
+				gain[cv] = dq
+				candidates = append(candidates, cv)
+			}
+
+			best, bestGain := cu, 0.0
+			for _, c := range candidates {
+				if dq := gain[c]; dq > bestGain {
+					best, bestGain = c, dq
+				}
+			}
+
+			comm[u] = best
+			for li := range g.layers {
+				strengths[li].commOut[best] += strengths[li].out[u]
+				strengths[li].commIn[best] += strengths[li].in[u]
+			}
+			if best != cu {
+				moved = true
+			}
+		}
+	}
+
+	return toCommunities(comm)
+}
+
+// qDirectedMultiplex returns the modularity of g at the given
+// resolution, defined as the weighted sum over layers of each layer's
+// directed modularity, using communities to define the partition over
+// g's own nodes if it is not nil, or the communities already held by g
+// otherwise.
+func qDirectedMultiplex(g *ReducedDirectedMultiplex, communities [][]graph.Node, resolution float64) float64 {
+	if g == nil {
+		return math.NaN()
+	}
+	var q float64
+	for _, l := range g.layers {
+		layer := &ReducedDirected{nodes: g.nodes, edges: l.edges}
+		lq := qDirected(layer, communities, resolution)
+		if lq != lq { // NaN: an empty layer contributes nothing.
+			continue
+		}
+		q += l.weight * lq
+	}
+	return q
+}
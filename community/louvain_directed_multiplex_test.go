@@ -0,0 +1,170 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/internal/ordered"
+	"github.com/gonum/graph/simple"
+)
+
+// directedFrom builds a simple.DirectedGraph from a []set adjacency
+// list, as used throughout this package's tests.
+func directedFrom(g []set) *simple.DirectedGraph {
+	dg := simple.NewDirectedGraph(0, 0)
+	for u, e := range g {
+		if !dg.Has(simple.Node(u)) {
+			dg.AddNode(simple.Node(u))
+		}
+		for v := range e {
+			dg.SetEdge(simple.Edge{F: simple.Node(u), T: simple.Node(v), W: 1})
+		}
+	}
+	return dg
+}
+
+func communitiesOf(r *ReducedDirectedMultiplex) [][]graph.Node {
+	c := r.Communities()
+	for _, s := range c {
+		sort.Sort(ordered.ByID(s))
+	}
+	sort.Sort(ordered.BySliceIDs(c))
+	return c
+}
+
+func communitiesOfDirected(r *ReducedDirected) [][]graph.Node {
+	c := r.Communities()
+	for _, s := range c {
+		sort.Sort(ordered.ByID(s))
+	}
+	sort.Sort(ordered.BySliceIDs(c))
+	return c
+}
+
+var communityDirectedMultiplexQTests = []struct {
+	name   string
+	g      []set
+	layers int
+}{
+	{name: "zachary", g: zachary, layers: 2},
+	{name: "blondel", g: blondel, layers: 3},
+}
+
+// TestLouvainDirectedMultiplexSingleLayer checks that replicating a
+// graph across layers, with only one layer given non-zero weight,
+// reproduces the single-layer LouvainDirected result.
+func TestLouvainDirectedMultiplexSingleLayer(t *testing.T) {
+	for _, test := range communityDirectedMultiplexQTests {
+		dg := directedFrom(test.g)
+
+		layers := make([]Layer, test.layers)
+		for i := range layers {
+			var w float64
+			if i == 0 {
+				w = 1
+			}
+			layers[i] = Layer{Graph: dg, Weight: w}
+		}
+
+		gotSingle := LouvainDirected(dg, 1, rand.New(rand.NewSource(1)))
+		gotMultiplex := LouvainDirectedMultiplex(layers, 1, rand.New(rand.NewSource(1)))
+
+		single := communitiesOfDirected(gotSingle)
+		multiplex := communitiesOf(gotMultiplex)
+
+		if !reflect.DeepEqual(single, multiplex) {
+			t.Errorf("%s: multiplex with a single non-zero layer weight does not match single-layer result:\n\tgot: %v\n\twant:%v",
+				test.name, multiplex, single)
+		}
+	}
+}
+
+// conflictingLayers returns two directed graphs over the shared node
+// set {0, 1, 2, 3}: friendship gives 0-1 and 2-3 each a strong mutual
+// edge, and conflict gives 0-1 alone a mutual edge of weight c. Used
+// together as multiplex layers, conflict is intended to be given a
+// negative Layer.Weight, so that its otherwise-reinforcing tie between
+// 0 and 1 instead drives them apart.
+func conflictingLayers(c float64) (friendship, conflict *simple.DirectedGraph) {
+	friendship = simple.NewDirectedGraph(0, 0)
+	conflict = simple.NewDirectedGraph(0, 0)
+	for _, n := range []graph.Node{simple.Node(0), simple.Node(1), simple.Node(2), simple.Node(3)} {
+		friendship.AddNode(n)
+		conflict.AddNode(n)
+	}
+	for _, e := range []simple.Edge{
+		{F: simple.Node(0), T: simple.Node(1), W: 3},
+		{F: simple.Node(1), T: simple.Node(0), W: 3},
+		{F: simple.Node(2), T: simple.Node(3), W: 3},
+		{F: simple.Node(3), T: simple.Node(2), W: 3},
+	} {
+		friendship.SetEdge(e)
+	}
+	for _, e := range []simple.Edge{
+		{F: simple.Node(0), T: simple.Node(1), W: c},
+		{F: simple.Node(1), T: simple.Node(0), W: c},
+	} {
+		conflict.SetEdge(e)
+	}
+	return friendship, conflict
+}
+
+// TestLouvainDirectedMultiplexNegativeLayer checks that a layer given
+// a negative Weight drives the nodes it connects apart, rather than
+// merely contributing a smaller or zero pull in the same direction as
+// the graph's other layers. Two layers share the node set {0, 1, 2,
+// 3}: friendship ties 0-1 and 2-3 each with a strong mutual edge, and
+// conflict ties only 0-1. With conflict given a positive weight it
+// reinforces friendship and 0 and 1 stay merged; with the same edge
+// given a negative weight instead, it must pull them into different
+// communities, while the unrelated 2-3 tie is unaffected either way.
+func TestLouvainDirectedMultiplexNegativeLayer(t *testing.T) {
+	friendship, conflict := conflictingLayers(4)
+
+	bestOf := func(layers []Layer, seed int64) *ReducedDirectedMultiplex {
+		var (
+			best  *ReducedDirectedMultiplex
+			bestQ = math.Inf(-1)
+		)
+		src := rand.New(rand.NewSource(seed))
+		for i := 0; i < 20; i++ {
+			r := LouvainDirectedMultiplex(layers, 1, src)
+			if q := Q(r, nil, 1); q > bestQ {
+				bestQ, best = q, r
+			}
+		}
+		return best
+	}
+
+	groupOf := func(r *ReducedDirectedMultiplex) map[int]int {
+		group := make(map[int]int)
+		for gid, c := range r.Communities() {
+			for _, n := range c {
+				group[n.ID()] = gid
+			}
+		}
+		return group
+	}
+
+	positive := groupOf(bestOf([]Layer{{Graph: friendship, Weight: 1}, {Graph: conflict, Weight: 1}}, 1))
+	if positive[0] != positive[1] {
+		t.Fatal("expected a positively-weighted conflict layer to reinforce merging 0 and 1, precondition for this test does not hold")
+	}
+
+	negativeResult := bestOf([]Layer{{Graph: friendship, Weight: 1}, {Graph: conflict, Weight: -1}}, 1)
+	negative := groupOf(negativeResult)
+	if negative[0] == negative[1] {
+		t.Errorf("expected a negatively-weighted conflict layer to drive 0 and 1 apart, got same community: %v", negativeResult.Communities())
+	}
+	if negative[2] != negative[3] {
+		t.Errorf("expected the unrelated 2-3 tie to remain merged regardless of the conflict layer: %v", negativeResult.Communities())
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+// pairBridge returns a directed graph of two mutually-connected pairs,
+// {0, 2} and {1, 3}, joined only by a single mutual edge of weight
+// bridgeWeight directly between 0 and 1.
+func pairBridge(bridgeWeight float64) *simple.DirectedGraph {
+	g := simple.NewDirectedGraph(0, 0)
+	for _, e := range []simple.Edge{
+		{F: simple.Node(0), T: simple.Node(2), W: 1},
+		{F: simple.Node(2), T: simple.Node(0), W: 1},
+		{F: simple.Node(1), T: simple.Node(3), W: 1},
+		{F: simple.Node(3), T: simple.Node(1), W: 1},
+		{F: simple.Node(0), T: simple.Node(1), W: bridgeWeight},
+		{F: simple.Node(1), T: simple.Node(0), W: bridgeWeight},
+	} {
+		g.SetEdge(e)
+	}
+	return g
+}
+
+// TestQDirectedSigned checks that the Gómez–Jensen–Arenas signed
+// modularity favors keeping 0 and 1 in a single community with the
+// rest of the graph when they are joined by a strong positive bridge,
+// but favors separating them once that bridge is revealed to be
+// antagonistic and given a negative weight, even though the rest of
+// the graph, and the magnitude of the relationship, are unchanged.
+func TestQDirectedSigned(t *testing.T) {
+	merged := [][]graph.Node{
+		{simple.Node(0), simple.Node(1), simple.Node(2), simple.Node(3)},
+	}
+	split := [][]graph.Node{
+		{simple.Node(0), simple.Node(2)},
+		{simple.Node(1), simple.Node(3)},
+	}
+
+	const tol = 1e-9
+
+	cordial := reduceDirected(pairBridge(3))
+	if qMerged, qSplit := Q(cordial, merged, 1), Q(cordial, split, 1); qSplit >= qMerged+tol {
+		t.Errorf("expected a strong positive bridge to be kept within one community: Q(merged)=%.4v Q(split)=%.4v", qMerged, qSplit)
+	}
+
+	antagonistic := reduceDirected(pairBridge(-1))
+	if qMerged, qSplit := Q(antagonistic, merged, 1), Q(antagonistic, split, 1); qSplit <= qMerged+tol {
+		t.Errorf("expected an antagonistic bridge to be split across communities: Q(merged)=%.4v Q(split)=%.4v", qMerged, qSplit)
+	}
+}
+
+// zacharyFactionHi and zacharyFactionOfficer are Zachary's (1977)
+// documented post-fission factions of the karate club: the members
+// who sided with the instructor, Mr. Hi, and those who sided with the
+// club president, the officer.
+var (
+	zacharyFactionHi      = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 11, 12, 13, 16, 17, 19, 21}
+	zacharyFactionOfficer = []int{9, 14, 15, 18, 20, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33}
+)
+
+// signedZachary returns a signed variant of the zachary karate club
+// graph: every tie that crosses the documented Hi/officer faction
+// split is given weight -1, as an antagonistic relationship strained
+// by the conflict that eventually fractured the club, while every
+// tie within a faction keeps its original weight of 1.
+func signedZachary() *simple.DirectedGraph {
+	faction := make(map[int]bool, 34)
+	for _, id := range zacharyFactionHi {
+		faction[id] = true
+	}
+
+	g := directedFrom(zachary)
+	dg := simple.NewDirectedGraph(0, 0)
+	for _, u := range g.Nodes() {
+		dg.AddNode(u)
+	}
+	for _, u := range g.Nodes() {
+		for _, v := range g.From(u) {
+			w := 1.0
+			if faction[u.ID()] != faction[v.ID()] {
+				w = -1
+			}
+			dg.SetEdge(simple.Edge{F: u, T: v, W: w})
+		}
+	}
+	return dg
+}
+
+// TestQDirectedSignedZachary checks the Gómez–Jensen–Arenas signed
+// modularity at the scale of the zachary karate club graph, rather
+// than only a small synthetic example: it must favor the documented
+// Hi/officer faction split over keeping the whole club as a single
+// community once the cross-faction ties are marked antagonistic.
+func TestQDirectedSignedZachary(t *testing.T) {
+	toNodes := func(ids []int) []graph.Node {
+		nodes := make([]graph.Node, len(ids))
+		for i, id := range ids {
+			nodes[i] = simple.Node(id)
+		}
+		return nodes
+	}
+
+	merged := [][]graph.Node{toNodes(append(append([]int{}, zacharyFactionHi...), zacharyFactionOfficer...))}
+	split := [][]graph.Node{toNodes(zacharyFactionHi), toNodes(zacharyFactionOfficer)}
+
+	const tol = 1e-9
+
+	signed := reduceDirected(signedZachary())
+	if qMerged, qSplit := Q(signed, merged, 1), Q(signed, split, 1); qSplit <= qMerged+tol {
+		t.Errorf("expected the documented faction split to beat the single-community partition: Q(merged)=%.4v Q(split)=%.4v", qMerged, qSplit)
+	}
+}
+
+// TestLouvainDirectedSignedZachary checks that LouvainDirected itself
+// separates a real ambivalent club member, node 8, from the officer
+// faction once its ties to that faction are marked antagonistic, even
+// though LouvainDirected merges node 8 into the officer faction when
+// the same graph is treated as entirely positive. Node 8 (Zachary's
+// actor 9) is a documented borderline case: it is tied to both
+// factions and is commonly misclassified by unsigned modularity
+// maximization on this graph.
+func TestLouvainDirectedSignedZachary(t *testing.T) {
+	bestOf := func(g graph.Directed, seed int64) *ReducedDirected {
+		var (
+			best  *ReducedDirected
+			bestQ = math.Inf(-1)
+		)
+		src := rand.New(rand.NewSource(seed))
+		for i := 0; i < 20; i++ {
+			r := LouvainDirected(g, 1, src)
+			if q := Q(r, nil, 1); q > bestQ {
+				bestQ, best = q, r
+			}
+		}
+		return best
+	}
+
+	groupOf := func(r *ReducedDirected) map[int]int {
+		group := make(map[int]int)
+		for gid, c := range r.Communities() {
+			for _, n := range c {
+				group[n.ID()] = gid
+			}
+		}
+		return group
+	}
+
+	unsigned := groupOf(bestOf(directedFrom(zachary), 1))
+	if unsigned[8] != unsigned[32] {
+		t.Fatal("expected unsigned Louvain to merge node 8 with the officer faction, precondition for this test does not hold")
+	}
+
+	signed := groupOf(bestOf(signedZachary(), 1))
+	if signed[8] == signed[32] {
+		t.Errorf("expected signed Louvain to separate node 8 from the officer faction once their tie is antagonistic, got same community: %v", signed)
+	}
+}
+
+// TestLouvainDirectedSigned checks that LouvainDirected itself, not
+// just the Q function in isolation, separates 0 and 1 into different
+// communities when they are joined by an antagonistic edge, having
+// merged them when that same edge was a strong positive tie.
+func TestLouvainDirectedSigned(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		bridgeWeight float64
+		wantSplit    bool
+	}{
+		{name: "cordial", bridgeWeight: 3, wantSplit: false},
+		{name: "antagonistic", bridgeWeight: -1, wantSplit: true},
+	} {
+		g := pairBridge(test.bridgeWeight)
+
+		var (
+			best  *ReducedDirected
+			bestQ = math.Inf(-1)
+		)
+		src := rand.New(rand.NewSource(1))
+		for i := 0; i < 20; i++ {
+			r := LouvainDirected(g, 1, src)
+			if q := Q(r, nil, 1); q > bestQ {
+				bestQ, best = q, r
+			}
+		}
+
+		group := make(map[int]int)
+		for gid, c := range best.Communities() {
+			for _, n := range c {
+				group[n.ID()] = gid
+			}
+		}
+		gotSplit := group[0] != group[1]
+		if gotSplit != test.wantSplit {
+			t.Errorf("%s: got split=%t, want split=%t (communities: %v)", test.name, gotSplit, test.wantSplit, best.Communities())
+		}
+	}
+}
@@ -252,7 +252,7 @@ func TestLouvainDirected(t *testing.T) {
 			}
 
 			var qs []float64
-			for p := r; p != nil; p = p.Expanded() {
+			for p := ReducedGraph(r); p != nil; p = p.Expanded() {
 				qs = append(qs, Q(p, nil, 1))
 			}
 
@@ -274,16 +274,16 @@ func TestLouvainDirected(t *testing.T) {
 		}
 
 		var levels []level
-		for p := got; p != nil; p = p.Expanded() {
+		for p := ReducedGraph(got); p != nil; p = p.Expanded() {
 			var communities [][]graph.Node
-			if p.parent != nil {
-				communities = p.parent.Communities()
+			if finer := p.Expanded(); finer != nil {
+				communities = p.Communities()
 				for _, c := range communities {
 					sort.Sort(ordered.ByID(c))
 				}
 				sort.Sort(ordered.BySliceIDs(communities))
 			} else {
-				communities = reduceDirected(g, nil).Communities()
+				communities = reduceDirected(g).Communities()
 			}
 			q := Q(p, nil, 1)
 			if math.IsNaN(q) {
@@ -0,0 +1,359 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+// ReducedUndirected is an undirected graph of communities derived from
+// a parent graph by reduction. Each node in a ReducedUndirected holds
+// the set of original-graph nodes that were merged into it.
+type ReducedUndirected struct {
+	nodes     []community
+	edges     map[[2]int]float64
+	structure [][]graph.Node
+	parent    *ReducedUndirected
+}
+
+// reduceUndirected returns the identity reduction of g: a
+// ReducedUndirected in which every node of g is its own community,
+// with node IDs assigned in g's own node ID order, and structure
+// holding each node's own identity since there is no finer-grained
+// graph below it.
+func reduceUndirected(g graph.Undirected) *ReducedUndirected {
+	nodes := g.Nodes()
+	r := &ReducedUndirected{
+		nodes:     make([]community, len(nodes)),
+		edges:     make(map[[2]int]float64),
+		structure: make([][]graph.Node, len(nodes)),
+	}
+	ids := make([]int, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+	sortInts(ids)
+	byID := make(map[int]int, len(nodes))
+	for i, id := range ids {
+		byID[id] = i
+		r.nodes[i] = community{id: i, nodes: []graph.Node{simple.Node(id)}}
+		r.structure[i] = []graph.Node{simple.Node(id)}
+	}
+	weight := positiveWeightFuncFor(g)
+	for _, u := range nodes {
+		for _, v := range g.From(u) {
+			w := weight(u, v)
+			if w == 0 {
+				continue
+			}
+			r.edges[edgeKey(byID[u.ID()], byID[v.ID()])] += w
+		}
+	}
+	return r
+}
+
+// edgeKey returns a canonical, direction-independent key for the
+// undirected edge between communities u and v.
+func edgeKey(u, v int) [2]int {
+	if u > v {
+		u, v = v, u
+	}
+	return [2]int{u, v}
+}
+
+func (g *ReducedUndirected) Has(n graph.Node) bool {
+	id := n.ID()
+	return id >= 0 && id < len(g.nodes)
+}
+
+func (g *ReducedUndirected) Nodes() []graph.Node {
+	nodes := make([]graph.Node, len(g.nodes))
+	for i := range g.nodes {
+		nodes[i] = simple.Node(i)
+	}
+	return nodes
+}
+
+func (g *ReducedUndirected) From(n graph.Node) []graph.Node {
+	var to []graph.Node
+	for other := range g.nodes {
+		if other == n.ID() {
+			continue
+		}
+		if _, ok := g.edges[edgeKey(n.ID(), other)]; ok {
+			to = append(to, simple.Node(other))
+		}
+	}
+	return to
+}
+
+func (g *ReducedUndirected) HasEdgeBetween(x, y graph.Node) bool {
+	_, ok := g.edges[edgeKey(x.ID(), y.ID())]
+	return ok
+}
+
+func (g *ReducedUndirected) Edge(u, v graph.Node) graph.Edge {
+	w, ok := g.edges[edgeKey(u.ID(), v.ID())]
+	if !ok {
+		return nil
+	}
+	return simple.Edge{F: u, T: v, W: w}
+}
+
+func (g *ReducedUndirected) weight(u, v int) float64 {
+	return g.edges[edgeKey(u, v)]
+}
+
+// Communities returns the community structure of the graph in terms of
+// the original graph's nodes.
+func (g *ReducedUndirected) Communities() [][]graph.Node {
+	communities := make([][]graph.Node, len(g.nodes))
+	for i, c := range g.nodes {
+		nodes := make([]graph.Node, len(c.nodes))
+		copy(nodes, c.nodes)
+		communities[i] = nodes
+	}
+	return communities
+}
+
+// Structure returns the sub-community layout of the graph: the
+// element at a given node ID holds the nodes of Expanded() that were
+// merged to form that node.
+func (g *ReducedUndirected) Structure() [][]graph.Node {
+	structure := make([][]graph.Node, len(g.structure))
+	for i, s := range g.structure {
+		nodes := make([]graph.Node, len(s))
+		copy(nodes, s)
+		structure[i] = nodes
+	}
+	return structure
+}
+
+// Expanded returns the finer-grained ReducedGraph that this graph was
+// constructed from, or nil if this is the first level of reduction.
+func (g *ReducedUndirected) Expanded() ReducedGraph {
+	if g.parent == nil {
+		return nil
+	}
+	return g.parent
+}
+
+// LouvainUndirected returns the community structure of g at the given
+// resolution using the Louvain modularization algorithm. If src is not
+// nil it is used to control the randomized iteration order of the
+// local moving phase; if src is nil, global randomness is used.
+//
+// LouvainUndirected returns nil if g has no nodes.
+func LouvainUndirected(g graph.Undirected, resolution float64, src *rand.Rand) *ReducedUndirected {
+	if len(g.Nodes()) == 0 {
+		return nil
+	}
+
+	rnd := rand.Intn
+	if src != nil {
+		rnd = src.Intn
+	}
+
+	current := reduceUndirected(g)
+	for {
+		communities := louvainUndirectedLocalMove(current, resolution, rnd)
+		if len(communities) == len(current.nodes) {
+			return current
+		}
+
+		next := aggregateUndirected(current, communities)
+		next.parent = current
+		current = next
+	}
+}
+
+func aggregateUndirected(cur *ReducedUndirected, communities [][]int) *ReducedUndirected {
+	expanded := make([][]graph.Node, len(communities))
+	for cid, members := range communities {
+		for _, id := range members {
+			expanded[cid] = append(expanded[cid], cur.nodes[id].nodes...)
+		}
+	}
+
+	r := &ReducedUndirected{
+		nodes:     make([]community, len(communities)),
+		edges:     make(map[[2]int]float64),
+		structure: make([][]graph.Node, len(communities)),
+	}
+	nodeCommunity := make(map[int]int, len(cur.nodes))
+	for cid, members := range communities {
+		r.nodes[cid] = community{id: cid, nodes: expanded[cid]}
+		for _, id := range members {
+			nodeCommunity[id] = cid
+			r.structure[cid] = append(r.structure[cid], simple.Node(id))
+		}
+	}
+	for key, w := range cur.edges {
+		u, v := nodeCommunity[key[0]], nodeCommunity[key[1]]
+		r.edges[edgeKey(u, v)] += w
+	}
+	return r
+}
+
+// undirectedDegrees returns the degree of every node of g, indexed by
+// node ID, along with m2, the sum of all node degrees (i.e. twice g's
+// overall edge weight).
+func undirectedDegrees(g *ReducedUndirected) (deg []float64, m2 float64) {
+	n := len(g.nodes)
+	deg = make([]float64, n)
+	for key, w := range g.edges {
+		if key[0] == key[1] {
+			deg[key[0]] += 2 * w
+			m2 += 2 * w
+			continue
+		}
+		deg[key[0]] += w
+		deg[key[1]] += w
+		m2 += 2 * w
+	}
+	return deg, m2
+}
+
+// louvainUndirectedLocalMove performs the local moving phase of the
+// Louvain algorithm on g, returning the resulting communities as
+// slices of node IDs in g.
+func louvainUndirectedLocalMove(g *ReducedUndirected, resolution float64, rnd func(int) int) [][]int {
+	deg, m2 := undirectedDegrees(g)
+	return louvainUndirectedLocalMoveWithDegrees(g, resolution, rnd, deg, m2)
+}
+
+// louvainUndirectedLocalMoveWithDegrees performs the local moving
+// phase of the Louvain algorithm on g exactly as
+// louvainUndirectedLocalMove does, except that it normalizes the
+// resolution term of the modularity gain against the caller-supplied
+// degrees and m2 rather than recomputing them from g's own edges. This
+// lets a refinement pass restricted to a subgraph still maximize
+// modularity against the total weight of the network the subgraph was
+// drawn from, rather than against the subgraph's own, smaller,
+// internal weight.
+func louvainUndirectedLocalMoveWithDegrees(g *ReducedUndirected, resolution float64, rnd func(int) int, deg []float64, m2 float64) [][]int {
+	n := len(g.nodes)
+	comm := make([]int, n)
+	for i := range comm {
+		comm[i] = i
+	}
+
+	if m2 == 0 {
+		return toCommunities(comm)
+	}
+
+	commDeg := make([]float64, n)
+	copy(commDeg, deg)
+
+	moved := true
+	for moved {
+		moved = false
+		order := rndPerm(n, rnd)
+		for _, u := range order {
+			cu := comm[u]
+			commDeg[cu] -= deg[u]
+
+			gain := make(map[int]float64)
+			gain[cu] = 0
+			// candidates holds community IDs in the fixed,
+			// rnd-independent order in which they were first
+			// seen, so that the best-move comparison below is
+			// not subject to Go's randomized map iteration
+			// order.
+			candidates := []int{cu}
+			for v := 0; v < n; v++ {
+				if v == u {
+					continue
+				}
+				cv := comm[v]
+				if _, ok := gain[cv]; ok {
+					continue
+				}
+				var kIn float64
+				for w, cw := range comm {
+					if cw == cv {
+						kIn += g.weight(u, w)
+					}
+				}
+				gain[cv] = kIn/m2 - resolution*deg[u]*commDeg[cv]/(m2*m2/2)
+				candidates = append(candidates, cv)
+			}
+
+			best, bestGain := cu, 0.0
+			for _, c := range candidates {
+				if dq := gain[c]; dq > bestGain {
+					best, bestGain = c, dq
+				}
+			}
+
+			comm[u] = best
+			commDeg[best] += deg[u]
+			if best != cu {
+				moved = true
+			}
+		}
+	}
+
+	return toCommunities(comm)
+}
+
+// qUndirected returns the modularity of g at the given resolution,
+// using communities to define the partition over g's own nodes if it
+// is not nil, or the communities already held by g otherwise.
+func qUndirected(g *ReducedUndirected, communities [][]graph.Node, resolution float64) float64 {
+	if g == nil {
+		return math.NaN()
+	}
+	group := make(map[int]int, len(g.nodes))
+	if communities == nil {
+		for i := range g.nodes {
+			group[i] = i
+		}
+	} else {
+		for gid, nodes := range communities {
+			for _, n := range nodes {
+				group[n.ID()] = gid
+			}
+		}
+	}
+
+	deg := make(map[int]float64)
+	var m2 float64
+	for key, w := range g.edges {
+		if key[0] == key[1] {
+			deg[key[0]] += 2 * w
+			m2 += 2 * w
+			continue
+		}
+		deg[key[0]] += w
+		deg[key[1]] += w
+		m2 += 2 * w
+	}
+	if m2 == 0 {
+		return math.NaN()
+	}
+
+	var internal float64
+	for key, w := range g.edges {
+		if group[key[0]] == group[key[1]] {
+			internal += 2 * w
+		}
+	}
+
+	commDeg := make(map[int]float64)
+	for id, gid := range group {
+		commDeg[gid] += deg[id]
+	}
+	var correction float64
+	for _, d := range commDeg {
+		correction += d * d
+	}
+
+	return internal/m2 - resolution*correction/(m2*m2)
+}
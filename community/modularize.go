@@ -0,0 +1,60 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+
+	"github.com/gonum/graph"
+)
+
+// ReducedGraph is a graph reduction of an original graph formed by
+// collapsing groups of its nodes into communities, as performed by
+// this package's modularization functions. It is implemented by
+// *ReducedDirected and *ReducedUndirected.
+type ReducedGraph interface {
+	graph.Graph
+
+	// Communities returns the community structure of the graph in
+	// terms of the original graph's nodes.
+	Communities() [][]graph.Node
+
+	// Structure returns the sub-community layout of the graph: the
+	// element at a given node ID holds the nodes of the next
+	// finer-grained reduction, as returned by Expanded, that were
+	// merged to form that node.
+	Structure() [][]graph.Node
+
+	// Expanded returns the finer-grained reduction that this graph
+	// was built from, or nil if this is the first level of reduction.
+	Expanded() ReducedGraph
+}
+
+// Modularize returns the community structure of g at the given
+// resolution using the Louvain modularization algorithm, dispatching
+// to LouvainDirected or LouvainUndirected depending on whether g is a
+// graph.Directed or a graph.Undirected. If src is not nil it is used
+// to control the randomized iteration order of the local moving phase;
+// if src is nil, global randomness is used.
+//
+// Modularize returns nil if g has no nodes.
+func Modularize(g graph.Graph, resolution float64, src *rand.Rand) ReducedGraph {
+	switch g := g.(type) {
+	case graph.Directed:
+		r := LouvainDirected(g, resolution, src)
+		if r == nil {
+			return nil
+		}
+		return r
+	case graph.Undirected:
+		r := LouvainUndirected(g, resolution, src)
+		if r == nil {
+			return nil
+		}
+		return r
+	default:
+		panic("community: graph is neither directed nor undirected")
+	}
+}
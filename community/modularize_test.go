@@ -0,0 +1,77 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestModularizeDirected checks that Modularize dispatches to
+// LouvainDirected for a directed graph and that the resulting
+// ReducedGraph's Structure, at each level, accounts for every node of
+// the level below it exactly once.
+func TestModularizeDirected(t *testing.T) {
+	g := directedFrom(zachary)
+
+	r := Modularize(g, 1, rand.New(rand.NewSource(1)))
+	if r == nil {
+		t.Fatal("Modularize returned nil for a non-empty graph")
+	}
+	if _, ok := r.(*ReducedDirected); !ok {
+		t.Fatalf("Modularize did not dispatch to LouvainDirected for a directed graph, got %T", r)
+	}
+
+	for p := r; p != nil; p = p.Expanded() {
+		seen := make(map[int]bool)
+		for _, sub := range p.Structure() {
+			for _, n := range sub {
+				if seen[n.ID()] {
+					t.Errorf("node %d appears in more than one sub-community", n.ID())
+				}
+				seen[n.ID()] = true
+			}
+		}
+		if finer := p.Expanded(); finer != nil {
+			if len(seen) != len(finer.Communities()) {
+				t.Errorf("Structure does not cover every node of the finer-grained graph: got %d, want %d", len(seen), len(finer.Communities()))
+			}
+		}
+	}
+}
+
+// TestModularizeUndirected is the undirected counterpart of
+// TestModularizeDirected: it checks that Modularize dispatches to
+// LouvainUndirected for an undirected graph and that the resulting
+// ReducedGraph's Structure, at each level, accounts for every node of
+// the level below it exactly once.
+func TestModularizeUndirected(t *testing.T) {
+	g := undirectedFrom(zachary)
+
+	r := Modularize(g, 1, rand.New(rand.NewSource(1)))
+	if r == nil {
+		t.Fatal("Modularize returned nil for a non-empty graph")
+	}
+	if _, ok := r.(*ReducedUndirected); !ok {
+		t.Fatalf("Modularize did not dispatch to LouvainUndirected for an undirected graph, got %T", r)
+	}
+
+	for p := r; p != nil; p = p.Expanded() {
+		seen := make(map[int]bool)
+		for _, sub := range p.Structure() {
+			for _, n := range sub {
+				if seen[n.ID()] {
+					t.Errorf("node %d appears in more than one sub-community", n.ID())
+				}
+				seen[n.ID()] = true
+			}
+		}
+		if finer := p.Expanded(); finer != nil {
+			if len(seen) != len(finer.Communities()) {
+				t.Errorf("Structure does not cover every node of the finer-grained graph: got %d, want %d", len(seen), len(finer.Communities()))
+			}
+		}
+	}
+}
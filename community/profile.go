@@ -0,0 +1,174 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/internal/ordered"
+)
+
+// ProfileFn returns the community structure of a graph at the given
+// resolution, gamma, along with a score characterizing the strength of
+// that structure.
+type ProfileFn func(gamma float64) (communities [][]graph.Node, score float64)
+
+// ModularScore returns a ProfileFn that modularizes g with Louvain at a
+// given resolution, retaining the highest-modularity result of iter
+// repetitions of the randomized algorithm. If src is not nil it is
+// used to control the randomized iteration order of the local moving
+// phase; if src is nil, global randomness is used. g must be a
+// graph.Directed or a graph.Undirected.
+//
+// The score reported for a resolution is the total internal edge
+// weight of the retained community structure, as given by weight.
+func ModularScore(g graph.Graph, weight func(x, y graph.Node) float64, iter int, src *rand.Rand) ProfileFn {
+	return func(gamma float64) ([][]graph.Node, float64) {
+		var (
+			communities [][]graph.Node
+			bestQ       = math.Inf(-1)
+		)
+		switch g := g.(type) {
+		case graph.Directed:
+			for i := 0; i < iter; i++ {
+				r := LouvainDirected(g, gamma, src)
+				if q := Q(r, nil, gamma); q > bestQ {
+					bestQ = q
+					communities = r.Communities()
+				}
+			}
+		case graph.Undirected:
+			for i := 0; i < iter; i++ {
+				r := LouvainUndirected(g, gamma, src)
+				if q := Q(r, nil, gamma); q > bestQ {
+					bestQ = q
+					communities = r.Communities()
+				}
+			}
+		default:
+			panic("community: graph is neither directed nor undirected")
+		}
+		return communities, internalWeight(communities, weight)
+	}
+}
+
+// internalWeight returns the total weight of edges within each
+// community of communities, as given by weight.
+func internalWeight(communities [][]graph.Node, weight func(x, y graph.Node) float64) float64 {
+	var score float64
+	for _, c := range communities {
+		for _, u := range c {
+			for _, v := range c {
+				score += weight(u, v)
+			}
+		}
+	}
+	return score
+}
+
+// ProfilePoint is an interval of the resolution parameter over which a
+// ProfileFn's community structure was found not to change. Adjacent
+// points may hold the same structure; they are not merged.
+type ProfilePoint struct {
+	Low, High   float64
+	Score       float64
+	Communities [][]graph.Node
+}
+
+// Profile returns the community structure profile of fn across the
+// resolution range [low, high], recording the intervals over which the
+// structure is stable, sorted by Low and then by High. Bisection of the range is done
+// logarithmically if log is true, and linearly otherwise. Recursion
+// stops, and the current interval's endpoints are recorded as distinct
+// points, once the interval's width is less than tol — high-low in
+// linear mode, or log(high)-log(low) in log mode.
+func Profile(fn ProfileFn, log bool, tol, low, high float64) ([]ProfilePoint, error) {
+	if low >= high {
+		return nil, errors.New("community: low must be less than high")
+	}
+	if log && low <= 0 {
+		return nil, errors.New("community: non-positive low end of logarithmic range")
+	}
+
+	loC, loScore := fn(low)
+	hiC, hiScore := fn(high)
+
+	var points []ProfilePoint
+	profile(fn, log, tol, low, loC, loScore, high, hiC, hiScore, &points)
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Low != points[j].Low {
+			return points[i].Low < points[j].Low
+		}
+		return points[i].High < points[j].High
+	})
+	return points, nil
+}
+
+func profile(fn ProfileFn, log bool, tol, low float64, loC [][]graph.Node, loScore float64, high float64, hiC [][]graph.Node, hiScore float64, points *[]ProfilePoint) {
+	var width float64
+	if log {
+		width = math.Log(high) - math.Log(low)
+	} else {
+		width = high - low
+	}
+	if width < tol {
+		*points = append(*points, ProfilePoint{Low: low, High: low, Score: loScore, Communities: loC})
+		*points = append(*points, ProfilePoint{Low: high, High: high, Score: hiScore, Communities: hiC})
+		return
+	}
+
+	var mid float64
+	if log {
+		mid = math.Exp((math.Log(low) + math.Log(high)) / 2)
+	} else {
+		mid = (low + high) / 2
+	}
+	midC, midScore := fn(mid)
+
+	// Only fold the whole span into one stable point once the
+	// midpoint agrees with both endpoints; a structure that matches
+	// at low and high but differs at mid must still be bisected so
+	// that an interior transition is not hidden.
+	if sameCommunities(loC, midC) && sameCommunities(midC, hiC) {
+		*points = append(*points, ProfilePoint{Low: low, High: high, Score: loScore, Communities: loC})
+		return
+	}
+
+	profile(fn, log, tol, low, loC, loScore, mid, midC, midScore, points)
+	profile(fn, log, tol, mid, midC, midScore, high, hiC, hiScore, points)
+}
+
+// sameCommunities reports whether a and b describe the same partition
+// of nodes, independent of community and node ordering.
+func sameCommunities(a, b [][]graph.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = cloneCommunities(a)
+	b = cloneCommunities(b)
+	for _, c := range a {
+		sort.Sort(ordered.ByID(c))
+	}
+	for _, c := range b {
+		sort.Sort(ordered.ByID(c))
+	}
+	sort.Sort(ordered.BySliceIDs(a))
+	sort.Sort(ordered.BySliceIDs(b))
+	return reflect.DeepEqual(a, b)
+}
+
+func cloneCommunities(a [][]graph.Node) [][]graph.Node {
+	b := make([][]graph.Node, len(a))
+	for i, c := range a {
+		b[i] = append([]graph.Node(nil), c...)
+	}
+	return b
+}
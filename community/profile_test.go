@@ -0,0 +1,96 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+func TestProfile(t *testing.T) {
+	g := directedFrom(zachary)
+	weight := positiveWeightFuncFor(g)
+	fn := ModularScore(g, weight, 10, rand.New(rand.NewSource(1)))
+
+	const tol = 1e-2
+	points, err := Profile(fn, false, tol, 0.1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error from Profile: %v", err)
+	}
+	if len(points) == 0 {
+		t.Fatal("Profile returned no points")
+	}
+
+	if points[0].Low != 0.1 {
+		t.Errorf("unexpected low end of first point: got:%v want:0.1", points[0].Low)
+	}
+	if got := points[len(points)-1].High; got != 2 {
+		t.Errorf("unexpected high end of last point: got:%v want:2", got)
+	}
+	// Points abut, except across a transition narrowed to less than
+	// tol, whose two endpoints are recorded as separate points.
+	for i := 1; i < len(points); i++ {
+		if gap := points[i].Low - points[i-1].High; gap < 0 || gap >= tol {
+			t.Errorf("gap in resolution coverage between points %d and %d: %v != %v",
+				i-1, i, points[i-1].High, points[i].Low)
+		}
+	}
+}
+
+// TestProfileNonMonotonic checks that Profile does not hide an interior
+// transition in a non-monotonic ProfileFn that agrees at both ends of a
+// bisected range but disagrees at the midpoint, a case that can arise
+// for community structure across gamma reappearing at distant
+// resolutions.
+func TestProfileNonMonotonic(t *testing.T) {
+	split := [][]graph.Node{
+		{simple.Node(0), simple.Node(1)},
+		{simple.Node(2), simple.Node(3)},
+	}
+	merged := [][]graph.Node{
+		{simple.Node(0), simple.Node(1), simple.Node(2), simple.Node(3)},
+	}
+
+	// fn agrees on split at both ends of [0, 1] but reports merged
+	// in the interior band around the midpoint.
+	fn := func(gamma float64) ([][]graph.Node, float64) {
+		if gamma >= 0.4 && gamma <= 0.6 {
+			return merged, 2
+		}
+		return split, 1
+	}
+
+	points, err := Profile(fn, false, 0.05, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error from Profile: %v", err)
+	}
+
+	var foundInterior bool
+	for _, p := range points {
+		if sameCommunities(p.Communities, merged) {
+			foundInterior = true
+			break
+		}
+	}
+	if !foundInterior {
+		t.Errorf("Profile did not report the interior merged structure, endpoints incorrectly folded into one stable point: %#v", points)
+	}
+}
+
+func TestProfileBadRange(t *testing.T) {
+	g := directedFrom(zachary)
+	weight := positiveWeightFuncFor(g)
+	fn := ModularScore(g, weight, 1, nil)
+
+	if _, err := Profile(fn, false, 1e-2, 2, 1); err == nil {
+		t.Error("expected error for low >= high")
+	}
+	if _, err := Profile(fn, true, 1e-2, 0, 2); err == nil {
+		t.Error("expected error for non-positive low end of log range")
+	}
+}
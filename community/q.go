@@ -0,0 +1,28 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import "github.com/gonum/graph"
+
+// Q returns the modularity of the graph r at the given resolution. If
+// communities is not nil, it is used in place of r's own community
+// structure to partition the nodes of r; otherwise r's communities are
+// used. For a multiplex reduced graph, Q is the weighted sum of each
+// layer's own modularity. Q returns NaN if r is a nil reduced graph,
+// matching the empty-graph convention of this package's modularization
+// functions. Q panics if r is not one of the reduced graph types
+// returned by this package's modularization functions.
+func Q(r interface{}, communities [][]graph.Node, resolution float64) float64 {
+	switch r := r.(type) {
+	case *ReducedDirected:
+		return qDirected(r, communities, resolution)
+	case *ReducedUndirected:
+		return qUndirected(r, communities, resolution)
+	case *ReducedDirectedMultiplex:
+		return qDirectedMultiplex(r, communities, resolution)
+	default:
+		panic("community: unknown reduced graph type")
+	}
+}
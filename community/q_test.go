@@ -0,0 +1,29 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math"
+	"testing"
+)
+
+// TestQNilGraph checks that Q returns NaN rather than panicking when
+// given a nil reduced graph, matching the "returns nil if g has no
+// nodes" convention of the modularization entry points that produce
+// reduced graphs.
+func TestQNilGraph(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		r    interface{}
+	}{
+		{name: "directed", r: (*ReducedDirected)(nil)},
+		{name: "undirected", r: (*ReducedUndirected)(nil)},
+		{name: "directed multiplex", r: (*ReducedDirectedMultiplex)(nil)},
+	} {
+		if q := Q(test.r, nil, 1); !math.IsNaN(q) {
+			t.Errorf("%s: expected NaN for nil reduced graph, got %v", test.name, q)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+
+	"github.com/gonum/graph"
+)
+
+// SLMDirected returns the community structure of g at the given
+// resolution using the Smart Local Moving algorithm of Waltman & van
+// Eck, an alternative to LouvainDirected's move phase that refines
+// each community found by local moving with a further round of local
+// moving restricted to that community's own nodes. This lets SLM split
+// communities that local moving alone would otherwise freeze,
+// including internally disconnected ones. If src is not nil it is used
+// to control the randomized iteration order of local moving; if src is
+// nil, global randomness is used.
+//
+// SLMDirected returns nil if g has no nodes.
+func SLMDirected(g graph.Directed, resolution float64, src *rand.Rand) *ReducedDirected {
+	if len(g.Nodes()) == 0 {
+		return nil
+	}
+
+	rnd := rand.Intn
+	if src != nil {
+		rnd = src.Intn
+	}
+
+	current := reduceDirected(g)
+	for {
+		moved := louvainDirectedLocalMove(current, resolution, rnd)
+		if len(moved) == len(current.nodes) {
+			return current
+		}
+
+		refined := refineDirectedCommunities(current, moved, resolution, rnd)
+		next := aggregateDirected(current, refined)
+		next.parent = current
+		current = next
+	}
+}
+
+// refineDirectedCommunities splits each of communities by running a
+// further round of local moving on the subgraph induced by its own
+// members, returning the resulting, generally finer-grained, list of
+// communities. The local moving is normalized against g's own
+// out-strength, in-strength and total weight rather than the induced
+// subgraph's, so that refinement maximizes modularity of the actual
+// network rather than of the community's internal weight alone.
+func refineDirectedCommunities(g *ReducedDirected, communities [][]int, resolution float64, rnd func(int) int) [][]int {
+	out, in, total := directedDegrees(g)
+
+	var refined [][]int
+	for _, members := range communities {
+		if len(members) <= 1 {
+			refined = append(refined, members)
+			continue
+		}
+
+		sub, index := inducedDirected(g, members)
+		subOut := make([]signedWeight, len(members))
+		subIn := make([]signedWeight, len(members))
+		for i, id := range members {
+			subOut[i] = out[id]
+			subIn[i] = in[id]
+		}
+		for _, sub := range louvainDirectedLocalMoveWithDegrees(sub, resolution, rnd, subOut, subIn, total) {
+			group := make([]int, len(sub))
+			for i, id := range sub {
+				group[i] = index[id]
+			}
+			refined = append(refined, group)
+		}
+	}
+	return refined
+}
+
+// inducedDirected returns the subgraph of g induced by members, along
+// with the mapping from the subgraph's node IDs back to g's.
+func inducedDirected(g *ReducedDirected, members []int) (sub *ReducedDirected, index []int) {
+	index = append([]int(nil), members...)
+	pos := make(map[int]int, len(members))
+	for i, id := range members {
+		pos[id] = i
+	}
+
+	sub = &ReducedDirected{
+		nodes: make([]community, len(members)),
+		edges: make(map[[2]int]signedWeight),
+	}
+	for i, id := range members {
+		sub.nodes[i] = g.nodes[id]
+	}
+	for key, w := range g.edges {
+		u, okU := pos[key[0]]
+		v, okV := pos[key[1]]
+		if okU && okV {
+			rkey := [2]int{u, v}
+			e := sub.edges[rkey]
+			e.pos += w.pos
+			e.neg += w.neg
+			sub.edges[rkey] = e
+		}
+	}
+	return sub, index
+}
@@ -0,0 +1,100 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+
+	"github.com/gonum/graph"
+)
+
+// SLMUndirected is the undirected counterpart of SLMDirected: it
+// returns the community structure of g at the given resolution using
+// the Smart Local Moving algorithm of Waltman & van Eck in place of
+// LouvainUndirected's move phase. See SLMDirected for details.
+//
+// SLMUndirected returns nil if g has no nodes.
+func SLMUndirected(g graph.Undirected, resolution float64, src *rand.Rand) *ReducedUndirected {
+	if len(g.Nodes()) == 0 {
+		return nil
+	}
+
+	rnd := rand.Intn
+	if src != nil {
+		rnd = src.Intn
+	}
+
+	current := reduceUndirected(g)
+	for {
+		moved := louvainUndirectedLocalMove(current, resolution, rnd)
+		if len(moved) == len(current.nodes) {
+			return current
+		}
+
+		refined := refineUndirectedCommunities(current, moved, resolution, rnd)
+		next := aggregateUndirected(current, refined)
+		next.parent = current
+		current = next
+	}
+}
+
+// refineUndirectedCommunities splits each of communities by running a
+// further round of local moving on the subgraph induced by its own
+// members, returning the resulting, generally finer-grained, list of
+// communities. The local moving is normalized against g's own degrees
+// and m2 rather than the induced subgraph's, so that refinement
+// maximizes modularity of the actual network rather than of the
+// community's internal weight alone.
+func refineUndirectedCommunities(g *ReducedUndirected, communities [][]int, resolution float64, rnd func(int) int) [][]int {
+	deg, m2 := undirectedDegrees(g)
+
+	var refined [][]int
+	for _, members := range communities {
+		if len(members) <= 1 {
+			refined = append(refined, members)
+			continue
+		}
+
+		sub, index := inducedUndirected(g, members)
+		subDeg := make([]float64, len(members))
+		for i, id := range members {
+			subDeg[i] = deg[id]
+		}
+		for _, sub := range louvainUndirectedLocalMoveWithDegrees(sub, resolution, rnd, subDeg, m2) {
+			group := make([]int, len(sub))
+			for i, id := range sub {
+				group[i] = index[id]
+			}
+			refined = append(refined, group)
+		}
+	}
+	return refined
+}
+
+// inducedUndirected returns the subgraph of g induced by members, along
+// with the mapping from the subgraph's node IDs back to g's.
+func inducedUndirected(g *ReducedUndirected, members []int) (sub *ReducedUndirected, index []int) {
+	index = append([]int(nil), members...)
+	pos := make(map[int]int, len(members))
+	for i, id := range members {
+		pos[id] = i
+	}
+
+	sub = &ReducedUndirected{
+		nodes: make([]community, len(members)),
+		edges: make(map[[2]int]float64),
+	}
+	for i, id := range members {
+		sub.nodes[i] = g.nodes[id]
+	}
+	for key, w := range g.edges {
+		u, okU := pos[key[0]]
+		v, okV := pos[key[1]]
+		if okU && okV {
+			sub.edges[edgeKey(u, v)] += w
+		}
+	}
+	return sub, index
+}
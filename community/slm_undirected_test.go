@@ -0,0 +1,110 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/simple"
+)
+
+// undirectedFrom builds a simple.UndirectedGraph from a []set
+// adjacency list, as used throughout this package's tests.
+func undirectedFrom(g []set) *simple.UndirectedGraph {
+	ug := simple.NewUndirectedGraph(0, 0)
+	for u, e := range g {
+		if !ug.Has(simple.Node(u)) {
+			ug.AddNode(simple.Node(u))
+		}
+		for v := range e {
+			ug.SetEdge(simple.Edge{F: simple.Node(u), T: simple.Node(v), W: 1})
+		}
+	}
+	return ug
+}
+
+// TestSLMUndirected is the undirected analogue of TestSLMDirected: it
+// exercises SLMUndirected on a graph shaped after Louvain's known
+// failure mode, a chain of three dense clusters joined by single
+// bridge nodes, whose local moving can leave a community internally
+// disconnected. As with the directed case, the failure is rare, so
+// the test first searches a wide range of seeds for one on which
+// plain LouvainUndirected actually does produce a disconnected
+// community, confirming the premise, before checking that
+// SLMUndirected avoids it on every seed in that same range.
+func TestSLMUndirected(t *testing.T) {
+	g := []set{
+		0:  linksTo(1, 2, 3),
+		1:  linksTo(2, 3),
+		2:  linksTo(3),
+		3:  linksTo(11),
+		4:  linksTo(5, 6),
+		5:  linksTo(6),
+		6:  linksTo(12),
+		7:  linksTo(8, 9, 10),
+		8:  linksTo(9, 10),
+		9:  linksTo(10),
+		10: nil,
+		11: linksTo(4),
+		12: linksTo(7),
+	}
+	ug := undirectedFrom(g)
+
+	const seeds = 2000
+
+	foundLouvainFailure := false
+	for i := 0; i < seeds && !foundLouvainFailure; i++ {
+		src := rand.New(rand.NewSource(int64(i)))
+		r := LouvainUndirected(ug, 1, src)
+		for _, c := range r.Communities() {
+			if !isConnectedUndirected(ug, c) {
+				foundLouvainFailure = true
+				break
+			}
+		}
+	}
+	if !foundLouvainFailure {
+		t.Fatalf("expected plain LouvainUndirected to produce a disconnected community within %d seeds, precondition for this test does not hold", seeds)
+	}
+
+	for i := 0; i < seeds; i++ {
+		src := rand.New(rand.NewSource(int64(i)))
+		r := SLMUndirected(ug, 1, src)
+		for _, c := range r.Communities() {
+			if !isConnectedUndirected(ug, c) {
+				t.Errorf("seed %d: SLM produced an internally disconnected community: %v", i, c)
+			}
+		}
+	}
+}
+
+// isConnectedUndirected reports whether nodes, a subset of g's nodes,
+// induces a connected subgraph of g.
+func isConnectedUndirected(g graph.Undirected, nodes []graph.Node) bool {
+	if len(nodes) <= 1 {
+		return true
+	}
+	in := make(map[int]bool, len(nodes))
+	for _, n := range nodes {
+		in[n.ID()] = true
+	}
+
+	seen := make(map[int]bool, len(nodes))
+	stack := []graph.Node{nodes[0]}
+	seen[nodes[0].ID()] = true
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, v := range g.From(u) {
+			if in[v.ID()] && !seen[v.ID()] {
+				seen[v.ID()] = true
+				stack = append(stack, v)
+			}
+		}
+	}
+	return len(seen) == len(nodes)
+}
@@ -0,0 +1,106 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package community
+
+import "github.com/gonum/graph"
+
+// set is a set of node IDs used to describe the edges of a test graph
+// as an adjacency list.
+type set map[int]struct{}
+
+// linksTo returns a set containing each of ids.
+func linksTo(ids ...int) set {
+	s := make(set, len(ids))
+	for _, id := range ids {
+		s[id] = struct{}{}
+	}
+	return s
+}
+
+// structure is a single expected community structure for a graph at a
+// given resolution.
+type structure struct {
+	resolution  float64
+	memberships []set
+	want        float64
+	tol         float64
+}
+
+// level holds the modularity score and community membership expected
+// at one level of a Louvain reduction.
+type level struct {
+	q           float64
+	communities [][]graph.Node
+}
+
+// reverse reverses the order of elements in s in place.
+func reverse(s []float64) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// zachary is a directed representation of Zachary's karate club network
+// with edges oriented from lower- to higher-rank nodes so that the
+// direction can be read as "looks up to". See printgraphs_test.go for
+// how this and blondel below were generated.
+var zachary = []set{
+	0:  nil,
+	1:  linksTo(0, 2),
+	2:  linksTo(0, 32),
+	3:  linksTo(0, 1, 2),
+	4:  linksTo(0, 6, 10),
+	5:  linksTo(0, 6),
+	6:  linksTo(0, 5),
+	7:  linksTo(0, 1, 2, 3),
+	8:  linksTo(0, 2, 32, 33),
+	9:  linksTo(2, 33),
+	10: linksTo(0, 4, 5),
+	11: linksTo(0),
+	12: linksTo(0, 3),
+	13: linksTo(0, 1, 2, 3, 33),
+	14: linksTo(32, 33),
+	15: linksTo(32, 33),
+	16: linksTo(5, 6),
+	17: linksTo(0, 1),
+	18: linksTo(32, 33),
+	19: linksTo(0, 1, 33),
+	20: linksTo(32, 33),
+	21: linksTo(0, 1),
+	22: linksTo(32, 33),
+	23: linksTo(32, 33),
+	24: linksTo(25, 27, 31),
+	25: linksTo(23, 24, 31),
+	26: linksTo(29, 33),
+	27: linksTo(2, 23, 33),
+	28: linksTo(2, 31, 33),
+	29: linksTo(23, 32, 33),
+	30: linksTo(1, 8, 32, 33),
+	31: linksTo(0, 32, 33),
+	32: linksTo(33),
+	33: nil,
+}
+
+// blondel is a directed representation of the 16 node, two-community
+// example graph used in Blondel et al.'s original Louvain paper, with
+// edges oriented from lower- to higher-rank nodes.
+var blondel = []set{
+	0:  linksTo(2, 4, 5),
+	1:  linksTo(2, 4, 7),
+	2:  nil,
+	3:  linksTo(0, 7),
+	4:  linksTo(0, 2, 10),
+	5:  linksTo(0, 2, 7, 11),
+	6:  linksTo(2, 7, 11),
+	7:  linksTo(5),
+	8:  linksTo(10, 11, 14),
+	9:  linksTo(8, 12, 14),
+	10: linksTo(8, 11),
+	11: linksTo(8, 10),
+	12: linksTo(10, 13, 14),
+	13: linksTo(10, 11, 12, 14),
+	14: linksTo(8),
+	15: linksTo(8, 14),
+}
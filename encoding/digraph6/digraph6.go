@@ -0,0 +1,173 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package digraph6 implements marshaling and unmarshaling of graphs in
+// the digraph6 format.
+//
+// digraph6 is the directed counterpart of graph6: it packs the graph's
+// order, N, followed by the full row-major N×N adjacency matrix,
+// diagonal included (rather than graph6's upper triangle), into printable ASCII
+// characters in the range 63 ('?') to 126 ('~'), prefixed with '&' to
+// distinguish it from graph6 data.
+package digraph6
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/encoding/internal/sixbit"
+	"github.com/gonum/graph/internal/ordered"
+	"github.com/gonum/graph/simple"
+)
+
+// Graph is a digraph6-encoded graph. It implements graph.Graph directly
+// over its encoded string, without decoding into an intermediate
+// representation.
+type Graph string
+
+// Encode returns the digraph6 encoding of g. Encode returns an error if
+// g has a node with a negative ID, or more nodes than digraph6 can
+// represent.
+func Encode(g graph.Directed) (Graph, error) {
+	nodes := g.Nodes()
+	sort.Sort(ordered.ByID(nodes))
+
+	n := len(nodes)
+	index := make(map[int]int, n)
+	for i, u := range nodes {
+		if u.ID() < 0 {
+			return "", errors.New("digraph6: node with negative ID")
+		}
+		index[u.ID()] = i
+	}
+
+	bits := make([]bool, n*n)
+	for _, u := range nodes {
+		for _, v := range g.From(u) {
+			bits[pos(n, index[u.ID()], index[v.ID()])] = true
+		}
+	}
+
+	enc, err := sixbit.EncodeN("digraph6", n)
+	if err != nil {
+		return "", err
+	}
+	return Graph("&" + enc + sixbit.EncodeBits(bits)), nil
+}
+
+// Decode parses the digraph6-encoded graph held by s.
+func Decode(s string) (Graph, error) {
+	if _, _, err := decodeHeader(s); err != nil {
+		return "", err
+	}
+	return Graph(s), nil
+}
+
+// Nodes returns the nodes of g.
+func (g Graph) Nodes() []graph.Node {
+	n, _, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	nodes := make([]graph.Node, n)
+	for i := range nodes {
+		nodes[i] = simple.Node(i)
+	}
+	return nodes
+}
+
+// Has returns whether n is a node of g.
+func (g Graph) Has(n graph.Node) bool {
+	order, _, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	return n.ID() >= 0 && n.ID() < order
+}
+
+// From returns the nodes reachable from u.
+func (g Graph) From(u graph.Node) []graph.Node {
+	order, bits, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	var to []graph.Node
+	for v := 0; v < order; v++ {
+		if v == u.ID() {
+			continue
+		}
+		if bits[pos(order, u.ID(), v)] {
+			to = append(to, simple.Node(v))
+		}
+	}
+	return to
+}
+
+// To returns the nodes that have a directed edge to n.
+func (g Graph) To(n graph.Node) []graph.Node {
+	order, bits, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	var from []graph.Node
+	for u := 0; u < order; u++ {
+		if u == n.ID() {
+			continue
+		}
+		if bits[pos(order, u, n.ID())] {
+			from = append(from, simple.Node(u))
+		}
+	}
+	return from
+}
+
+// HasEdgeBetween returns whether an edge exists between x and y in
+// either direction.
+func (g Graph) HasEdgeBetween(x, y graph.Node) bool {
+	return g.HasEdgeFromTo(x, y) || g.HasEdgeFromTo(y, x)
+}
+
+// HasEdgeFromTo returns whether an edge exists from u to v.
+func (g Graph) HasEdgeFromTo(u, v graph.Node) bool {
+	order, bits, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	return bits[pos(order, u.ID(), v.ID())]
+}
+
+// Edge returns the edge from u to v if one exists, and nil otherwise.
+func (g Graph) Edge(u, v graph.Node) graph.Edge {
+	if !g.HasEdgeFromTo(u, v) {
+		return nil
+	}
+	return simple.Edge{F: u, T: v, W: 1}
+}
+
+// pos returns the index into the row-major, order×order adjacency bit
+// array of the edge from u to v.
+func pos(order, u, v int) int {
+	return u*order + v
+}
+
+// decodeHeader parses s's '&' marker and node-count prefix, returning
+// the order of the graph and the unpacked adjacency bits that follow
+// it.
+func decodeHeader(s string) (order int, bits []bool, err error) {
+	if len(s) == 0 || s[0] != '&' {
+		return 0, nil, errors.New("digraph6: missing '&' marker")
+	}
+	n, rest, err := sixbit.DecodeN("digraph6", s[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	want := n * n
+	bits = sixbit.DecodeBits(rest, want)
+	if len(bits) < want {
+		return 0, nil, fmt.Errorf("digraph6: short data for %d nodes", n)
+	}
+	return n, bits, nil
+}
@@ -0,0 +1,118 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package digraph6
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/internal/ordered"
+	"github.com/gonum/graph/simple"
+)
+
+// fixtures pairs digraph6 string literals with the edge sets they
+// encode.
+var fixtures = []struct {
+	data  string
+	order int
+	edges [][2]int
+}{
+	{data: "&@?", order: 1, edges: nil},
+	{data: "&A?", order: 2, edges: nil},
+	{data: "&AO", order: 2, edges: [][2]int{{0, 1}}},
+	{
+		// A 3-cycle: 0->1->2->0.
+		data:  "&BP_",
+		order: 3,
+		edges: [][2]int{{0, 1}, {1, 2}, {2, 0}},
+	},
+}
+
+func directedFrom(order int, edges [][2]int) *simple.DirectedGraph {
+	g := simple.NewDirectedGraph(0, 0)
+	for i := 0; i < order; i++ {
+		g.AddNode(simple.Node(i))
+	}
+	for _, e := range edges {
+		g.SetEdge(simple.Edge{F: simple.Node(e[0]), T: simple.Node(e[1]), W: 1})
+	}
+	return g
+}
+
+func sortedEdges(g graph.Directed) [][2]int {
+	nodes := g.Nodes()
+	sort.Sort(ordered.ByID(nodes))
+	var got [][2]int
+	for _, u := range nodes {
+		for _, v := range g.From(u) {
+			got = append(got, [2]int{u.ID(), v.ID()})
+		}
+	}
+	return got
+}
+
+func TestEncode(t *testing.T) {
+	for _, f := range fixtures {
+		g := directedFrom(f.order, f.edges)
+		got, err := Encode(g)
+		if err != nil {
+			t.Errorf("unexpected error encoding order %d graph: %v", f.order, err)
+			continue
+		}
+		if string(got) != f.data {
+			t.Errorf("unexpected encoding for order %d graph: got:%q want:%q", f.order, got, f.data)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, f := range fixtures {
+		g, err := Decode(f.data)
+		if err != nil {
+			t.Errorf("unexpected error decoding %q: %v", f.data, err)
+			continue
+		}
+		if len(g.Nodes()) != f.order {
+			t.Errorf("unexpected order for %q: got:%d want:%d", f.data, len(g.Nodes()), f.order)
+		}
+		got := sortedEdges(g)
+		if !reflect.DeepEqual(got, f.edges) {
+			t.Errorf("unexpected edges for %q: got:%v want:%v", f.data, got, f.edges)
+		}
+	}
+}
+
+func TestDecodeMissingMarker(t *testing.T) {
+	if _, err := Decode("A?"); err == nil {
+		t.Error("expected error decoding graph6 data as digraph6")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, f := range fixtures {
+		want := directedFrom(f.order, f.edges)
+		enc, err := Encode(want)
+		if err != nil {
+			t.Fatalf("unexpected error encoding order %d graph: %v", f.order, err)
+		}
+		got, err := Decode(string(enc))
+		if err != nil {
+			t.Fatalf("unexpected error decoding %q: %v", enc, err)
+		}
+		if !reflect.DeepEqual(sortedEdges(got), sortedEdges(want)) {
+			t.Errorf("edges not preserved by round trip through %q", enc)
+		}
+
+		reenc, err := Encode(got)
+		if err != nil {
+			t.Fatalf("unexpected error re-encoding %q: %v", enc, err)
+		}
+		if reenc != enc {
+			t.Errorf("encoding not stable under round trip: got:%q want:%q", reenc, enc)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graph6 implements marshaling and unmarshaling of graphs in
+// the graph6 format.
+//
+// graph6 is a compact ASCII encoding for small-to-medium undirected
+// simple graphs devised by Brendan McKay. It packs the graph's order,
+// N, followed by the upper triangle of its adjacency matrix, into
+// printable ASCII characters in the range 63 ('?') to 126 ('~'),
+// making it convenient for storing test fixtures as single-line string
+// literals and for interop with the nauty/bliss tool ecosystem.
+package graph6
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gonum/graph"
+	"github.com/gonum/graph/encoding/internal/sixbit"
+	"github.com/gonum/graph/internal/ordered"
+	"github.com/gonum/graph/simple"
+)
+
+// Graph is a graph6-encoded graph. It implements graph.Graph directly
+// over its encoded string, without decoding into an intermediate
+// representation.
+type Graph string
+
+// Encode returns the graph6 encoding of g. Encode returns an error if
+// g has a node with a negative ID, or more nodes than graph6 can
+// represent.
+func Encode(g graph.Graph) (Graph, error) {
+	nodes := g.Nodes()
+	sort.Sort(ordered.ByID(nodes))
+
+	n := len(nodes)
+	index := make(map[int]int, n)
+	for i, u := range nodes {
+		if u.ID() < 0 {
+			return "", errors.New("graph6: node with negative ID")
+		}
+		index[u.ID()] = i
+	}
+
+	bits := make([]bool, n*(n-1)/2)
+	pos := func(i, j int) int {
+		if i > j {
+			i, j = j, i
+		}
+		// Column-major upper triangle: (0,1) (0,2) (1,2) (0,3) (1,3) (2,3) ...
+		return j*(j-1)/2 + i
+	}
+	for _, u := range nodes {
+		for _, v := range g.From(u) {
+			bits[pos(index[u.ID()], index[v.ID()])] = true
+		}
+	}
+
+	enc, err := sixbit.EncodeN("graph6", n)
+	if err != nil {
+		return "", err
+	}
+	return Graph(enc + sixbit.EncodeBits(bits)), nil
+}
+
+// Decode parses the graph6-encoded graph held by s.
+func Decode(s string) (Graph, error) {
+	if _, _, err := decodeHeader(s); err != nil {
+		return "", err
+	}
+	return Graph(s), nil
+}
+
+// Nodes returns the nodes of g.
+func (g Graph) Nodes() []graph.Node {
+	n, _, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	nodes := make([]graph.Node, n)
+	for i := range nodes {
+		nodes[i] = simple.Node(i)
+	}
+	return nodes
+}
+
+// Has returns whether n is a node of g.
+func (g Graph) Has(n graph.Node) bool {
+	order, _, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	return n.ID() >= 0 && n.ID() < order
+}
+
+// From returns the nodes reachable from u.
+func (g Graph) From(u graph.Node) []graph.Node {
+	order, bits, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	var to []graph.Node
+	for v := 0; v < order; v++ {
+		if v == u.ID() {
+			continue
+		}
+		if bits[triPos(u.ID(), v)] {
+			to = append(to, simple.Node(v))
+		}
+	}
+	return to
+}
+
+// HasEdgeBetween returns whether an edge exists between x and y.
+func (g Graph) HasEdgeBetween(x, y graph.Node) bool {
+	_, bits, err := decodeHeader(string(g))
+	if err != nil {
+		panic(err)
+	}
+	return bits[triPos(x.ID(), y.ID())]
+}
+
+// Edge returns the edge from u to v if one exists, and nil otherwise.
+func (g Graph) Edge(u, v graph.Node) graph.Edge {
+	if !g.HasEdgeBetween(u, v) {
+		return nil
+	}
+	return simple.Edge{F: u, T: v, W: 1}
+}
+
+func triPos(i, j int) int {
+	if i > j {
+		i, j = j, i
+	}
+	return j*(j-1)/2 + i
+}
+
+// decodeHeader parses s's node-count prefix and returns the order of
+// the graph and the unpacked upper-triangle adjacency bits that follow
+// it.
+func decodeHeader(s string) (order int, bits []bool, err error) {
+	n, rest, err := sixbit.DecodeN("graph6", s)
+	if err != nil {
+		return 0, nil, err
+	}
+	want := n * (n - 1) / 2
+	bits = sixbit.DecodeBits(rest, want)
+	if len(bits) < want {
+		return 0, nil, fmt.Errorf("graph6: short data for %d nodes", n)
+	}
+	return n, bits, nil
+}
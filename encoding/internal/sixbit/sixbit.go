@@ -0,0 +1,103 @@
+// Copyright ©2016 The gonum Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sixbit implements the six-bits-per-byte printable encoding
+// and node-count varint shared by the graph6 and digraph6 formats.
+package sixbit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EncodeN returns the graph6/digraph6 encoding of the node count n: a
+// single byte offset by 63 for n<63, '~' followed by 3 such bytes for
+// n<2^18, and '~~' followed by 6 such bytes for n<2^36; a negative n or
+// one of 2^36 or more is an error. pkg names the calling package, and
+// is used to prefix any returned error.
+func EncodeN(pkg string, n int) (string, error) {
+	switch {
+	case n < 0:
+		return "", fmt.Errorf("%s: negative node count", pkg)
+	case n <= 62:
+		return string([]byte{byte(n + 63)}), nil
+	case n <= 1<<18-1:
+		return "~" + Encode6(n, 3), nil
+	case n <= 1<<36-1:
+		return "~~" + Encode6(n, 6), nil
+	default:
+		return "", fmt.Errorf("%s: node count too large to encode", pkg)
+	}
+}
+
+// Encode6 encodes n as nbytes printable bytes, six bits per byte, most
+// significant first, each offset by 63.
+func Encode6(n, nbytes int) string {
+	b := make([]byte, nbytes)
+	for i := nbytes - 1; i >= 0; i-- {
+		b[i] = byte(n&0x3f) + 63
+		n >>= 6
+	}
+	return string(b)
+}
+
+// EncodeBits packs bits six to a byte, most significant first, padding
+// the final byte with zero bits, and offsets each byte by 63.
+func EncodeBits(bits []bool) string {
+	b := make([]byte, (len(bits)+5)/6)
+	for i, set := range bits {
+		if set {
+			b[i/6] |= 1 << uint(5-i%6)
+		}
+	}
+	for i := range b {
+		b[i] += 63
+	}
+	return string(b)
+}
+
+// DecodeN parses s's node-count prefix, returning the decoded node
+// count and the remainder of s holding the packed adjacency bits. pkg
+// names the calling package, and is used to prefix any returned error.
+func DecodeN(pkg, s string) (n int, rest string, err error) {
+	if len(s) == 0 {
+		return 0, "", errors.New(pkg + ": empty data")
+	}
+	if s[0] != '~' {
+		return int(s[0]) - 63, s[1:], nil
+	}
+	if len(s) > 1 && s[1] == '~' {
+		if len(s) < 8 {
+			return 0, "", errors.New(pkg + ": short header")
+		}
+		return Decode6(s[2:8]), s[8:], nil
+	}
+	if len(s) < 4 {
+		return 0, "", errors.New(pkg + ": short header")
+	}
+	return Decode6(s[1:4]), s[4:], nil
+}
+
+// Decode6 decodes s, a sequence of printable bytes each offset by 63
+// holding six bits apiece, most significant first, into an integer.
+func Decode6(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n<<6 | int(s[i]-63)
+	}
+	return n
+}
+
+// DecodeBits unpacks rest, a sequence of bytes each holding six bits
+// offset by 63, into individual bits, most significant first.
+func DecodeBits(rest string, want int) []bool {
+	bits := make([]bool, 0, len(rest)*6)
+	for i := 0; i < len(rest); i++ {
+		c := rest[i] - 63
+		for b := 5; b >= 0; b-- {
+			bits = append(bits, c&(1<<uint(b)) != 0)
+		}
+	}
+	return bits
+}